@@ -0,0 +1,64 @@
+package yubikey
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+)
+
+const (
+	hmacKeyLen   = 20
+	hmacBlockLen = 64
+)
+
+// HMACKey is the 20 byte secret configured into a Yubikey's HMAC-SHA1
+// challenge-response slot.
+type HMACKey [hmacKeyLen]byte
+
+// NewHMACKey returns an HMACKey built from the first 20 bytes of b.
+func NewHMACKey(b []byte) HMACKey {
+	var k HMACKey
+	copy(k[:], b)
+	return k
+}
+
+// ChallengeResponse computes the response a Yubikey slot configured
+// for fixed 64 byte HMAC-SHA1 challenge-response would produce for
+// challenge. challenge is zero-padded, or truncated, to 64 bytes.
+func ChallengeResponse(key HMACKey, challenge []byte) [20]byte {
+	return hmacChalResp(key, challenge, false)
+}
+
+// VariableChallengeResponse is like ChallengeResponse, but for a slot
+// configured in variable-length input mode, where challenge may be
+// shorter than 64 bytes. Per the Yubico spec, a Yubikey in this mode
+// can't distinguish genuine trailing data from zero padding when a
+// challenge's last two bytes are equal, so in that case a 0x80 marker
+// byte is inserted ahead of the zero padding to disambiguate. Pass
+// variable matching the slot's configuration.
+func VariableChallengeResponse(key HMACKey, challenge []byte, variable bool) [20]byte {
+	return hmacChalResp(key, challenge, variable)
+}
+
+// hmacChalResp computes the HMAC-SHA1 of challenge, zero-padded (or
+// truncated) to the 64 byte block Yubikey challenge-response hashes.
+func hmacChalResp(key HMACKey, challenge []byte, variable bool) [20]byte {
+	buf := make([]byte, hmacBlockLen)
+	n := copy(buf, challenge)
+
+	if variable && n >= 2 && n < hmacBlockLen && challenge[n-1] == challenge[n-2] {
+		buf[n] = 0x80
+	}
+
+	return hmacSHA1(key[:], buf)
+}
+
+// hmacSHA1 computes the HMAC-SHA1 of msg under key, for arbitrary
+// length keys and messages.
+func hmacSHA1(key, msg []byte) [20]byte {
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+
+	var sum [20]byte
+	copy(sum[:], mac.Sum(nil))
+	return sum
+}
@@ -0,0 +1,122 @@
+package yubikey
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test cases 1-7 from RFC 2202, section 3 ("Test Cases for HMAC-SHA-1").
+var rfc2202Tests = []struct {
+	key  []byte
+	data []byte
+	out  string
+}{
+	{
+		bytes.Repeat([]byte{0x0b}, 20),
+		[]byte("Hi There"),
+		"b617318655057264e28bc0b6fb378c8ef146be00",
+	},
+	{
+		[]byte("Jefe"),
+		[]byte("what do ya want for nothing?"),
+		"effcdf6ae5eb2fa2d27416d5f184df9c259a7c79",
+	},
+	{
+		bytes.Repeat([]byte{0xaa}, 20),
+		bytes.Repeat([]byte{0xdd}, 50),
+		"125d7342b9ac11cd91a39af48aa17b4f63f175d3",
+	},
+	{
+		[]byte{
+			0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a,
+			0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14,
+			0x15, 0x16, 0x17, 0x18, 0x19,
+		},
+		bytes.Repeat([]byte{0xcd}, 50),
+		"4c9007f4026250c6bc8414f9bf50c86c2d7235da",
+	},
+	{
+		bytes.Repeat([]byte{0x0c}, 20),
+		[]byte("Test With Truncation"),
+		"4c1a03424b55e07fe7f27be1d58bb9324a9a5a04",
+	},
+	{
+		bytes.Repeat([]byte{0xaa}, 80),
+		[]byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		"aa4ae5e15272d00e95705637ce8a3b55ed402112",
+	},
+	{
+		bytes.Repeat([]byte{0xaa}, 80),
+		[]byte("Test Using Larger Than Block-Size Key and Larger Than One Block-Size Data"),
+		"e8e99d0f45237d786d6bbaa7965c7808bbff1a91",
+	},
+}
+
+func TestHMACSHA1(t *testing.T) {
+	for i, test := range rfc2202Tests {
+		want, err := hex.DecodeString(test.out)
+		if err != nil {
+			t.Fatalf("RFC 2202 test #%d has a malformed expected value: %v", i, err)
+		}
+
+		got := hmacSHA1(test.key, test.data)
+		if !bytes.Equal(got[:], want) {
+			t.Errorf("RFC 2202 test #%d failed: got: %x want: %x", i, got, want)
+		}
+	}
+}
+
+func TestChallengeResponsePadsChallenge(t *testing.T) {
+	key := NewHMACKey(bytes.Repeat([]byte{0x0b}, hmacKeyLen))
+
+	padded := make([]byte, hmacBlockLen)
+	copy(padded, []byte("Hi There"))
+
+	got := ChallengeResponse(key, []byte("Hi There"))
+	want := hmacSHA1(key[:], padded)
+	if got != want {
+		t.Errorf("ChallengeResponse didn't zero-pad short challenge: got: %x want: %x", got, want)
+	}
+}
+
+func TestChallengeResponseTruncatesChallenge(t *testing.T) {
+	key := NewHMACKey(bytes.Repeat([]byte{0x0b}, hmacKeyLen))
+	long := bytes.Repeat([]byte{0x42}, hmacBlockLen+16)
+
+	got := ChallengeResponse(key, long)
+	want := hmacSHA1(key[:], long[:hmacBlockLen])
+	if got != want {
+		t.Errorf("ChallengeResponse didn't truncate long challenge: got: %x want: %x", got, want)
+	}
+}
+
+func TestVariableChallengeResponseDisambiguatesRepeatedByte(t *testing.T) {
+	key := NewHMACKey(bytes.Repeat([]byte{0x0b}, hmacKeyLen))
+	challenge := []byte("ambiguous!!") // last two bytes equal
+
+	fixed := ChallengeResponse(key, challenge)
+	variable := VariableChallengeResponse(key, challenge, true)
+	if fixed == variable {
+		t.Error("VariableChallengeResponse should disambiguate a challenge ending in a repeated byte")
+	}
+
+	padded := make([]byte, hmacBlockLen)
+	n := copy(padded, challenge)
+	padded[n] = 0x80
+	want := hmacSHA1(key[:], padded)
+	if variable != want {
+		t.Errorf("VariableChallengeResponse got: %x want: %x", variable, want)
+	}
+}
+
+func TestVariableChallengeResponseMatchesFixedWhenUnambiguous(t *testing.T) {
+	key := NewHMACKey(bytes.Repeat([]byte{0x0b}, hmacKeyLen))
+	challenge := []byte("not ambiguous")
+
+	fixed := ChallengeResponse(key, challenge)
+	variable := VariableChallengeResponse(key, challenge, true)
+	if fixed != variable {
+		t.Errorf("expected matching responses for an unambiguous challenge: fixed: %x variable: %x", fixed, variable)
+	}
+}
@@ -0,0 +1,75 @@
+package yubikey
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// SQLStore is a Store backed by a database/sql table with columns
+// (public_id TEXT PRIMARY KEY, ctr INTEGER, use INTEGER). Callers are
+// responsible for creating the table and for wrapping an SQLStore in a
+// LockingStore if the underlying driver doesn't serialize writes.
+//
+// Put reads the existing row and then updates or inserts it as two
+// separate statements, with no transaction around the pair. That's
+// safe under LockingStore's mutex within a single process, but an
+// SQLStore is not safe to share across multiple processes or
+// connections pointed at the same table: two instances can each read
+// the same stale (ctr, use), both accept OTPs in the same counter
+// window, or race an INSERT after both see zero rows from UPDATE.
+// SQLStore is therefore a single-process persistence backend only; it
+// does not extend Validator's replay guarantee across processes.
+type SQLStore struct {
+	db    *sql.DB
+	table string
+}
+
+// validTableName matches the identifiers NewSQLStore accepts for
+// table. The table name is interpolated directly into the SQL text
+// (database/sql has no placeholder syntax for identifiers), so it must
+// never be built from untrusted input even though this check passes.
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewSQLStore returns a Store that records replay state in table, via
+// db. table must be a valid, developer-supplied SQL identifier; it is
+// rejected otherwise.
+func NewSQLStore(db *sql.DB, table string) (*SQLStore, error) {
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("yubikey: invalid table name %q", table)
+	}
+	return &SQLStore{db: db, table: table}, nil
+}
+
+// Get implements Store.
+func (s *SQLStore) Get(publicID string) (uint16, uint8, error) {
+	query := fmt.Sprintf("SELECT ctr, use FROM %s WHERE public_id = ?", s.table)
+
+	var ctr uint16
+	var use uint8
+	switch err := s.db.QueryRow(query, publicID).Scan(&ctr, &use); err {
+	case nil:
+		return ctr, use, nil
+	case sql.ErrNoRows:
+		return 0, 0, nil
+	default:
+		return 0, 0, err
+	}
+}
+
+// Put implements Store. See the SQLStore doc comment: this is not
+// atomic and is only safe for single-process use.
+func (s *SQLStore) Put(publicID string, ctr uint16, use uint8) error {
+	update := fmt.Sprintf("UPDATE %s SET ctr = ?, use = ? WHERE public_id = ?", s.table)
+	res, err := s.db.Exec(update, ctr, use, publicID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return nil
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (public_id, ctr, use) VALUES (?, ?, ?)", s.table)
+	_, err = s.db.Exec(insert, publicID, ctr, use)
+	return err
+}
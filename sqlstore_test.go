@@ -0,0 +1,178 @@
+package yubikey
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQLRow is the state backing a single public_id row in fakeSQLDB.
+type fakeSQLRow struct {
+	ctr uint16
+	use uint8
+}
+
+// fakeSQLDB is the shared backing store behind a fake DSN, so every
+// *sql.DB connection opened with the same name sees the same rows.
+type fakeSQLDB struct {
+	mu   sync.Mutex
+	rows map[string]fakeSQLRow
+}
+
+var fakeSQLDBs = struct {
+	mu sync.Mutex
+	m  map[string]*fakeSQLDB
+}{m: make(map[string]*fakeSQLDB)}
+
+func fakeSQLDBFor(name string) *fakeSQLDB {
+	fakeSQLDBs.mu.Lock()
+	defer fakeSQLDBs.mu.Unlock()
+
+	db, ok := fakeSQLDBs.m[name]
+	if !ok {
+		db = &fakeSQLDB{rows: make(map[string]fakeSQLRow)}
+		fakeSQLDBs.m[name] = db
+	}
+	return db
+}
+
+// fakeSQLDriver is a minimal database/sql/driver implementation of the
+// SELECT/UPDATE/INSERT statements SQLStore issues, just enough to
+// exercise Get and Put without a real database dependency.
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return &fakeSQLConn{db: fakeSQLDBFor(name)}, nil
+}
+
+func init() {
+	sql.Register("yubikeyfake", fakeSQLDriver{})
+}
+
+type fakeSQLConn struct {
+	db *fakeSQLDB
+}
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakeSQLConn: prepared statements not supported")
+}
+
+func (c *fakeSQLConn) Close() error { return nil }
+
+func (c *fakeSQLConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fakeSQLConn: transactions not supported")
+}
+
+func (c *fakeSQLConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT") {
+		return nil, errors.New("fakeSQLConn: unsupported query")
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	publicID := args[0].(string)
+	row, ok := c.db.rows[publicID]
+	if !ok {
+		return &fakeSQLRows{}, nil
+	}
+	return &fakeSQLRows{rows: []fakeSQLRow{row}}, nil
+}
+
+func (c *fakeSQLConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "UPDATE"):
+		publicID := args[2].(string)
+		if _, ok := c.db.rows[publicID]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		c.db.rows[publicID] = fakeSQLRow{ctr: uint16(args[0].(int64)), use: uint8(args[1].(int64))}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "INSERT"):
+		publicID := args[0].(string)
+		c.db.rows[publicID] = fakeSQLRow{ctr: uint16(args[1].(int64)), use: uint8(args[2].(int64))}
+		return driver.RowsAffected(1), nil
+	default:
+		return nil, errors.New("fakeSQLConn: unsupported query")
+	}
+}
+
+type fakeSQLRows struct {
+	rows []fakeSQLRow
+	i    int
+}
+
+func (r *fakeSQLRows) Columns() []string { return []string{"ctr", "use"} }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	dest[0] = int64(r.rows[r.i].ctr)
+	dest[1] = int64(r.rows[r.i].use)
+	r.i++
+	return nil
+}
+
+func newFakeSQLStore(t *testing.T, dsn string) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("yubikeyfake", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewSQLStore(db, "otp_state")
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+	return s
+}
+
+func TestNewSQLStoreRejectsBadTableName(t *testing.T) {
+	db, err := sql.Open("yubikeyfake", "TestNewSQLStoreRejectsBadTableName")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := NewSQLStore(db, "otp_state; DROP TABLE users"); err == nil {
+		t.Error("expected NewSQLStore to reject a non-identifier table name")
+	}
+}
+
+func TestSQLStoreGetUnknownID(t *testing.T) {
+	s := newFakeSQLStore(t, t.Name())
+
+	ctr, use, err := s.Get("unknown")
+	if err != nil || ctr != 0 || use != 0 {
+		t.Errorf("got (%d, %d, %v), want (0, 0, nil)", ctr, use, err)
+	}
+}
+
+func TestSQLStorePutThenGet(t *testing.T) {
+	s := newFakeSQLStore(t, t.Name())
+
+	if err := s.Put("id1", 1, 1); err != nil {
+		t.Fatalf("first Put failed: %v", err)
+	}
+	if ctr, use, err := s.Get("id1"); err != nil || ctr != 1 || use != 1 {
+		t.Fatalf("got (%d, %d, %v), want (1, 1, nil)", ctr, use, err)
+	}
+
+	if err := s.Put("id1", 2, 0); err != nil {
+		t.Fatalf("second Put failed: %v", err)
+	}
+	if ctr, use, err := s.Get("id1"); err != nil || ctr != 2 || use != 0 {
+		t.Fatalf("got (%d, %d, %v), want (2, 0, nil)", ctr, use, err)
+	}
+}
@@ -0,0 +1,164 @@
+package yubikey
+
+import (
+	"errors"
+	"sync"
+)
+
+// publicIDLen is the length, in ModHex characters, of the public ID
+// prefix of an OTP (the ModHex encoding of a 6 byte Uid).
+const publicIDLen = uidLen * 2
+
+var (
+	// ErrReplayed is returned by Validator.Validate when an OTP's
+	// (Ctr, Use) pair is not strictly greater than the last pair
+	// accepted for its public ID.
+	ErrReplayed = errors.New("yubikey: replayed otp")
+
+	// ErrUnknownID is returned by Validator.Validate when an OTP's
+	// public ID is not present in the Validator's Keyring.
+	ErrUnknownID = errors.New("yubikey: unknown public id")
+
+	// ErrBadKey is returned by Validator.Validate when an OTP cannot be
+	// decrypted under its public ID's key, for reasons other than a
+	// failed CRC check.
+	ErrBadKey = errors.New("yubikey: bad key")
+)
+
+// Keyring maps a Yubikey ModHex public ID to the AES key used to
+// decrypt OTPs from that device.
+type Keyring map[string]Key
+
+// Store persists, per public ID, the (Ctr, Use) pair of the last OTP a
+// Validator accepted, so that replayed OTPs can be rejected.
+//
+// A publicID that has never been stored must report (0, 0, nil) from
+// Get rather than an error.
+type Store interface {
+	Get(publicID string) (lastCtr uint16, lastUse uint8, err error)
+	Put(publicID string, ctr uint16, use uint8) error
+}
+
+// Validator verifies Yubikey OTPs against a Keyring, rejecting OTPs
+// whose key, CRC, or replay state don't check out.
+type Validator struct {
+	keys  Keyring
+	store Store
+
+	// mu serializes the read-compare-write sequence in Validate so that
+	// concurrent calls can't both accept OTPs that replay one another.
+	mu sync.Mutex
+}
+
+// NewValidator returns a Validator that decrypts OTPs using keys and
+// tracks replay state in store.
+func NewValidator(keys Keyring, store Store) *Validator {
+	return &Validator{keys: keys, store: store}
+}
+
+// Validate ModHex-decodes otp, looks up its key by public ID, verifies
+// its CRC, and atomically compares its (Ctr, Use) pair against the last
+// one accepted for that public ID, accepting only strictly increasing
+// pairs as defined by the Yubico OTP spec.
+func (v *Validator) Validate(otp string) (*Token, error) {
+	if len(otp) != publicIDLen+tokenLen*2 {
+		return nil, ErrUnknownID
+	}
+	publicID := otp[:publicIDLen]
+
+	key, ok := v.keys[publicID]
+	if !ok {
+		return nil, ErrUnknownID
+	}
+
+	token, err := NewOTP(otp[publicIDLen:]).Parse(key)
+	if err != nil {
+		if err == ErrBadCRC {
+			return nil, ErrBadCRC
+		}
+		return nil, ErrBadKey
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	lastCtr, lastUse, err := v.store.Get(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Ctr < lastCtr || (token.Ctr == lastCtr && token.Use <= lastUse) {
+		return nil, ErrReplayed
+	}
+	if err := v.store.Put(publicID, token.Ctr, token.Use); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// MemStore is an in-memory Store suitable for tests and single-process
+// deployments. The zero value is not ready to use; call NewMemStore.
+type MemStore struct {
+	mu   sync.Mutex
+	data map[string]memRecord
+}
+
+type memRecord struct {
+	ctr uint16
+	use uint8
+}
+
+// NewMemStore returns an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{data: make(map[string]memRecord)}
+}
+
+// Get implements Store.
+func (s *MemStore) Get(publicID string) (uint16, uint8, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.data[publicID]
+	return r.ctr, r.use, nil
+}
+
+// Put implements Store.
+func (s *MemStore) Put(publicID string, ctr uint16, use uint8) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[publicID] = memRecord{ctr: ctr, use: use}
+	return nil
+}
+
+// LockingStore wraps a Store with a mutex so that each individual Get
+// or Put call is serialized across goroutines, for Store
+// implementations (such as SQLStore) that don't already do so
+// themselves. It does not make a Get-then-Put sequence atomic; callers
+// that need that (like Validator.Validate) must hold their own lock
+// across the whole sequence.
+type LockingStore struct {
+	mu sync.Mutex
+	s  Store
+}
+
+// NewLockingStore returns a LockingStore wrapping s.
+func NewLockingStore(s Store) *LockingStore {
+	return &LockingStore{s: s}
+}
+
+// Get implements Store.
+func (l *LockingStore) Get(publicID string) (uint16, uint8, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.s.Get(publicID)
+}
+
+// Put implements Store.
+func (l *LockingStore) Put(publicID string, ctr uint16, use uint8) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.s.Put(publicID, ctr, use)
+}
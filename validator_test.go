@@ -0,0 +1,69 @@
+package yubikey
+
+import "testing"
+
+var validatorTestKey = NewKey([]byte{
+	0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x30, 0x31,
+	0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+})
+
+var validatorTestUid = NewUid([]byte{
+	0x16, 0xe1, 0xe5, 0xd9, 0xd3, 0x99,
+})
+
+const validatorTestPublicID = "bhubugtktekk"
+
+func newValidatorTestOTP(ctr uint16, use uint8) string {
+	token := NewToken(validatorTestUid, ctr, 0x2007, 0xe3, use, 0x226d)
+	return validatorTestPublicID + token.Generate(validatorTestKey).String()
+}
+
+func TestValidatorAccepts(t *testing.T) {
+	v := NewValidator(Keyring{validatorTestPublicID: validatorTestKey}, NewMemStore())
+
+	token, err := v.Validate(newValidatorTestOTP(1, 1))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if token.Counter() != 1 {
+		t.Errorf("got counter %d, want 1", token.Counter())
+	}
+}
+
+func TestValidatorRejectsReplay(t *testing.T) {
+	v := NewValidator(Keyring{validatorTestPublicID: validatorTestKey}, NewMemStore())
+
+	if _, err := v.Validate(newValidatorTestOTP(1, 1)); err != nil {
+		t.Fatalf("first Validate failed: %v", err)
+	}
+	if _, err := v.Validate(newValidatorTestOTP(1, 1)); err != ErrReplayed {
+		t.Errorf("replayed otp: got err %v, want %v", err, ErrReplayed)
+	}
+	if _, err := v.Validate(newValidatorTestOTP(1, 0)); err != ErrReplayed {
+		t.Errorf("stale use counter: got err %v, want %v", err, ErrReplayed)
+	}
+	if _, err := v.Validate(newValidatorTestOTP(2, 0)); err != nil {
+		t.Errorf("higher Ctr should be accepted, got err %v", err)
+	}
+}
+
+func TestValidatorUnknownID(t *testing.T) {
+	v := NewValidator(Keyring{}, NewMemStore())
+
+	if _, err := v.Validate(newValidatorTestOTP(1, 1)); err != ErrUnknownID {
+		t.Errorf("got err %v, want %v", err, ErrUnknownID)
+	}
+}
+
+func TestValidatorBadKey(t *testing.T) {
+	wrongKey := NewKey([]byte{
+		0x39, 0x38, 0x37, 0x36, 0x35, 0x34, 0x33, 0x32,
+		0x31, 0x30, 0x66, 0x65, 0x64, 0x63, 0x62, 0x61,
+	})
+	v := NewValidator(Keyring{validatorTestPublicID: wrongKey}, NewMemStore())
+
+	_, err := v.Validate(newValidatorTestOTP(1, 1))
+	if err != ErrBadKey && err != ErrBadCRC {
+		t.Errorf("got err %v, want ErrBadKey or ErrBadCRC", err)
+	}
+}
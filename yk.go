@@ -0,0 +1,242 @@
+// Package yubikey implements the functionality required to parse and
+// generate Yubikey one-time password (OTP) tokens, as described in the
+// Yubico OTP specification.
+package yubikey
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	uidLen   = 6
+	keyLen   = 16
+	tokenLen = 16
+
+	// crcOkResidual is the CRC16 residual left over when running crc16
+	// over a full, uncorrupted 16 byte token (data plus its own CRC).
+	crcOkResidual = 0xf0b8
+
+	modHexAlphabet = "cbdefghijklnrtuv"
+)
+
+var (
+	// ErrBadLen is returned when a byte slice being parsed into a Token
+	// is not exactly 16 bytes long.
+	ErrBadLen = errors.New("yubikey: invalid token length")
+
+	// ErrBadCRC is returned by OTP.Parse when the decrypted token fails
+	// its CRC16 check.
+	ErrBadCRC = errors.New("yubikey: bad crc")
+)
+
+// Uid is the 6 byte (48 bit) secret identifier burned into a Yubikey at
+// personalization time.
+type Uid [uidLen]byte
+
+// Key is the 16 byte AES-128 key used to encrypt and decrypt a Token.
+type Key [keyLen]byte
+
+// NewUid returns a Uid built from the first 6 bytes of b.
+func NewUid(b []byte) Uid {
+	var u Uid
+	copy(u[:], b)
+	return u
+}
+
+// NewKey returns a Key built from the first 16 bytes of b.
+func NewKey(b []byte) Key {
+	var k Key
+	copy(k[:], b)
+	return k
+}
+
+// Token is the 16 byte plaintext payload of a Yubikey OTP: the device's
+// Uid, its session counter and usage fields, a timestamp, pseudo-random
+// padding, and a trailing CRC16.
+type Token struct {
+	Uid   Uid
+	Ctr   uint16
+	Tstpl uint16
+	Tstph uint8
+	Use   uint8
+	Rnd   uint16
+	Crc   uint16
+}
+
+// NewToken builds a Token from its fields and fills in the CRC16.
+func NewToken(uid Uid, ctr uint16, tstpl uint16, tstph uint8, use uint8, rnd uint16) *Token {
+	t := &Token{
+		Uid:   uid,
+		Ctr:   ctr,
+		Tstpl: tstpl,
+		Tstph: tstph,
+		Use:   use,
+		Rnd:   rnd,
+	}
+	t.Crc = ^crc16(t.Bytes()[:14])
+	return t
+}
+
+// NewTokenFromBytes parses the 16 byte wire representation of a Token.
+func NewTokenFromBytes(b []byte) (*Token, error) {
+	if len(b) != tokenLen {
+		return nil, ErrBadLen
+	}
+
+	t := &Token{
+		Ctr:   binary.LittleEndian.Uint16(b[6:8]),
+		Tstpl: binary.LittleEndian.Uint16(b[8:10]),
+		Tstph: b[10],
+		Use:   b[11],
+		Rnd:   binary.LittleEndian.Uint16(b[12:14]),
+		Crc:   binary.LittleEndian.Uint16(b[14:16]),
+	}
+	copy(t.Uid[:], b[0:6])
+	return t, nil
+}
+
+// Bytes returns the 16 byte wire representation of t.
+func (t *Token) Bytes() []byte {
+	b := make([]byte, tokenLen)
+	copy(b[0:6], t.Uid[:])
+	binary.LittleEndian.PutUint16(b[6:8], t.Ctr)
+	binary.LittleEndian.PutUint16(b[8:10], t.Tstpl)
+	b[10] = t.Tstph
+	b[11] = t.Use
+	binary.LittleEndian.PutUint16(b[12:14], t.Rnd)
+	binary.LittleEndian.PutUint16(b[14:16], t.Crc)
+	return b
+}
+
+// Counter returns the session use counter, with the capslock flag
+// masked off.
+func (t *Token) Counter() uint16 {
+	return t.Ctr & 0x7fff
+}
+
+// Capslock reports whether the token was generated with the capslock
+// modifier, signalled by the top bit of Ctr.
+func (t *Token) Capslock() bool {
+	return t.Ctr&0x8000 != 0
+}
+
+// Crc16 computes the running CRC16 over the full 16 byte token,
+// including its own trailing Crc field. A correctly formed token always
+// yields crcOkResidual.
+func (t *Token) Crc16() uint16 {
+	return crc16(t.Bytes())
+}
+
+// Generate encrypts t under k and ModHex-encodes the result, producing
+// the OTP a Yubikey would type out.
+func (t *Token) Generate(k Key) *OTP {
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		// k is always 16 bytes, so aes.NewCipher cannot fail.
+		panic(err)
+	}
+
+	ciphertext := make([]byte, tokenLen)
+	block.Encrypt(ciphertext, t.Bytes())
+
+	return &OTP{otp: string(ModHexEncode(ciphertext))}
+}
+
+// OTP is a ModHex-encoded, AES-encrypted Token as produced by a Yubikey
+// or by Token.Generate.
+type OTP struct {
+	otp string
+}
+
+// NewOTP wraps the ModHex-encoded OTP string s for parsing.
+func NewOTP(s string) *OTP {
+	return &OTP{otp: s}
+}
+
+// String returns the ModHex-encoded OTP.
+func (otp *OTP) String() string {
+	return otp.otp
+}
+
+// Parse decrypts otp under k and validates its CRC16, returning the
+// enclosed Token.
+func (otp *OTP) Parse(k Key) (*Token, error) {
+	ciphertext := ModHexDecode([]byte(otp.otp))
+
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) != tokenLen {
+		return nil, ErrBadLen
+	}
+
+	plaintext := make([]byte, tokenLen)
+	block.Decrypt(plaintext, ciphertext)
+
+	t, err := NewTokenFromBytes(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if t.Crc16() != crcOkResidual {
+		return nil, ErrBadCRC
+	}
+
+	return t, nil
+}
+
+// crc16 computes the Yubico OTP CRC16 (CRC-16/X-25) over b.
+func crc16(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, by := range b {
+		crc ^= uint16(by)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// ModHexEncode returns the ModHex encoding of b.
+func ModHexEncode(b []byte) []byte {
+	out := make([]byte, 0, len(b)*2)
+	for _, by := range b {
+		out = append(out, modHexAlphabet[by>>4], modHexAlphabet[by&0x0f])
+	}
+	return out
+}
+
+// ModHexDecode returns the bytes represented by the ModHex string b.
+// Characters outside the ModHex alphabet decode as zero nibbles.
+func ModHexDecode(b []byte) []byte {
+	out := make([]byte, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		hi := bytes.IndexByte([]byte(modHexAlphabet), b[i])
+		lo := bytes.IndexByte([]byte(modHexAlphabet), b[i+1])
+		if hi < 0 {
+			hi = 0
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		out = append(out, byte(hi<<4|lo))
+	}
+	return out
+}
+
+// ModHexP reports whether every byte in b is a valid ModHex character.
+func ModHexP(b []byte) bool {
+	for _, by := range b {
+		if bytes.IndexByte([]byte(modHexAlphabet), by) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package ykval
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHTTPTimeout bounds a single validation URL's request when
+// Client.HTTPTimeout is unset.
+const defaultHTTPTimeout = 5 * time.Second
+
+// ErrNoValidURLs is returned by Client.Verify when the Client has no
+// validation URLs configured. When every configured URL fails, Verify
+// instead returns the error of the first failed request it receives.
+var ErrNoValidURLs = errors.New("ykval: no validation server returned a usable response")
+
+// errMismatch is returned for a single validation URL when its
+// response's echoed otp or nonce doesn't match the request, or its
+// signature doesn't verify; Verify passes it on if no URL succeeds.
+var errMismatch = errors.New("ykval: response otp, nonce or signature mismatch")
+
+// Response is a parsed Yubico Validation Protocol response.
+type Response struct {
+	Status    Status
+	OTP       string
+	Nonce     string
+	Timestamp string
+
+	fields map[string]string
+}
+
+// Client verifies OTPs against one or more Yubico Validation Protocol
+// 2.0 servers (YubiCloud or self-hosted).
+type Client struct {
+	ClientID int
+	URLs     []string
+
+	// SyncLevel and Timeout are sent as the optional sl= and timeout=
+	// request parameters when greater than zero.
+	SyncLevel int
+	Timeout   int
+
+	// HTTPTimeout bounds each validation URL's request, so a single
+	// unreachable or hanging URL can't stall Verify when the others
+	// already answered. Defaults to defaultHTTPTimeout when zero.
+	HTTPTimeout time.Duration
+
+	secret []byte
+}
+
+// NewClient returns a Client that signs requests to, and verifies
+// responses from, urls using the client ID and base64-encoded HMAC
+// secret issued for that ID.
+func NewClient(clientID int, secret string, urls []string) (*Client, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("ykval: bad secret: %v", err)
+	}
+
+	return &Client{
+		ClientID: clientID,
+		URLs:     urls,
+		secret:   key,
+	}, nil
+}
+
+// Verify submits otp to every configured validation URL in parallel
+// and returns the first response whose signature verifies and whose
+// echoed otp and nonce match the request.
+func (c *Client) Verify(otp string) (*Response, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		"id":    strconv.Itoa(c.ClientID),
+		"otp":   otp,
+		"nonce": nonce,
+	}
+	if c.SyncLevel > 0 {
+		params["sl"] = strconv.Itoa(c.SyncLevel)
+	}
+	if c.Timeout > 0 {
+		params["timeout"] = strconv.Itoa(c.Timeout)
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	query.Set("h", sign(c.secret, params))
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	ch := make(chan result, len(c.URLs))
+	for _, base := range c.URLs {
+		go func(base string) {
+			resp, err := c.verifyOne(base, query, otp, nonce)
+			ch <- result{resp, err}
+		}(base)
+	}
+
+	var firstErr error
+	for range c.URLs {
+		r := <-ch
+		if r.err == nil {
+			return r.resp, nil
+		}
+		if firstErr == nil {
+			firstErr = r.err
+		}
+	}
+	if firstErr == nil {
+		firstErr = ErrNoValidURLs
+	}
+	return nil, firstErr
+}
+
+func (c *Client) verifyOne(base string, query url.Values, otp, nonce string) (*Response, error) {
+	timeout := c.HTTPTimeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	httpClient := &http.Client{Timeout: timeout}
+
+	resp, err := httpClient.Get(base + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := parseResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if r.OTP != otp || r.Nonce != nonce {
+		return nil, errMismatch
+	}
+
+	signed := make(map[string]string, len(r.fields))
+	for k, v := range r.fields {
+		if k != "h" {
+			signed[k] = v
+		}
+	}
+	if !hmac.Equal([]byte(sign(c.secret, signed)), []byte(r.fields["h"])) {
+		return nil, errMismatch
+	}
+
+	return r, nil
+}
+
+// parseResponse parses the key=value, CRLF-delimited body of a
+// validation protocol response.
+func parseResponse(body []byte) (*Response, error) {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields[k] = v
+	}
+
+	return &Response{
+		Status:    Status(fields["status"]),
+		OTP:       fields["otp"],
+		Nonce:     fields["nonce"],
+		Timestamp: fields["t"],
+		fields:    fields,
+	}, nil
+}
+
+// newNonce returns a random 32 character hex nonce, as recommended by
+// the validation protocol spec (16-40 characters).
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,51 @@
+// Package ykval implements the Yubico Validation Protocol 2.0, the
+// key=value HTTP web service protocol spoken by YubiCloud and by
+// self-hosted Yubico validation servers, on top of the token codec and
+// replay-protected Validator in the parent yubikey package.
+package ykval
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"sort"
+	"strings"
+)
+
+// Status is a validation protocol response status, as sent in a
+// response's status= field.
+type Status string
+
+// Statuses defined by the Yubico Validation Protocol 2.0.
+const (
+	StatusOK                  Status = "OK"
+	StatusBadOTP              Status = "BAD_OTP"
+	StatusReplayedOTP         Status = "REPLAYED_OTP"
+	StatusBadSignature        Status = "BAD_SIGNATURE"
+	StatusMissingParameter    Status = "MISSING_PARAMETER"
+	StatusNoSuchClient        Status = "NO_SUCH_CLIENT"
+	StatusOperationNotAllowed Status = "OPERATION_NOT_ALLOWED"
+	StatusBackendError        Status = "BACKEND_ERROR"
+	StatusNotEnoughAnswers    Status = "NOT_ENOUGH_ANSWERS"
+	StatusReplayedRequest     Status = "REPLAYED_REQUEST"
+)
+
+// sign computes the protocol's request/response signature: the
+// base64-encoded HMAC-SHA1, under secret, of params formatted as
+// "key=value&key=value&..." with keys sorted alphabetically.
+func sign(secret []byte, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + params[k]
+	}
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write([]byte(strings.Join(parts, "&")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package ykval
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/waldyrious/yubikey"
+)
+
+// Server is an http.Handler implementing the /wsapi/2.0/verify endpoint
+// of the Yubico Validation Protocol 2.0, backed by a yubikey.Validator.
+type Server struct {
+	validator *yubikey.Validator
+	secrets   map[int][]byte
+}
+
+// NewServer returns a Server that validates OTPs with v, signing and
+// verifying requests for each client ID using its base64-decoded
+// secret from secrets.
+func NewServer(v *yubikey.Validator, secrets map[int][]byte) *Server {
+	return &Server{validator: v, secrets: secrets}
+}
+
+// ServeHTTP implements the /wsapi/2.0/verify endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	id, otp, nonce, h := q.Get("id"), q.Get("otp"), q.Get("nonce"), q.Get("h")
+
+	if id == "" || otp == "" || nonce == "" {
+		s.respond(w, otp, nonce, StatusMissingParameter, nil)
+		return
+	}
+
+	clientID, err := strconv.Atoi(id)
+	if err != nil {
+		s.respond(w, otp, nonce, StatusNoSuchClient, nil)
+		return
+	}
+
+	secret, ok := s.secrets[clientID]
+	if !ok {
+		s.respond(w, otp, nonce, StatusNoSuchClient, nil)
+		return
+	}
+
+	if h == "" {
+		s.respond(w, otp, nonce, StatusMissingParameter, nil)
+		return
+	}
+
+	params := map[string]string{"id": id, "otp": otp, "nonce": nonce}
+	if sl := q.Get("sl"); sl != "" {
+		params["sl"] = sl
+	}
+	if timeout := q.Get("timeout"); timeout != "" {
+		params["timeout"] = timeout
+	}
+	if !hmac.Equal([]byte(sign(secret, params)), []byte(h)) {
+		s.respond(w, otp, nonce, StatusBadSignature, secret)
+		return
+	}
+
+	_, err = s.validator.Validate(otp)
+	s.respond(w, otp, nonce, statusFromErr(err), secret)
+}
+
+// statusFromErr maps a yubikey.Validator error to the validation
+// protocol status that best describes it.
+func statusFromErr(err error) Status {
+	switch err {
+	case nil:
+		return StatusOK
+	case yubikey.ErrReplayed:
+		return StatusReplayedOTP
+	case yubikey.ErrUnknownID, yubikey.ErrBadCRC, yubikey.ErrBadKey:
+		return StatusBadOTP
+	default:
+		return StatusBackendError
+	}
+}
+
+// respond writes a signed key=value response. secret may be nil (e.g.
+// when the client ID itself couldn't be resolved), in which case the
+// response is sent unsigned.
+func (s *Server) respond(w http.ResponseWriter, otp, nonce string, status Status, secret []byte) {
+	params := map[string]string{
+		"t":      time.Now().UTC().Format(time.RFC3339Nano),
+		"otp":    otp,
+		"nonce":  nonce,
+		"status": string(status),
+	}
+	if secret != nil {
+		params["h"] = sign(secret, params)
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, k := range keys {
+		w.Write([]byte(k + "=" + params[k] + "\r\n"))
+	}
+}
@@ -0,0 +1,115 @@
+package ykval
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/waldyrious/yubikey"
+)
+
+// Sample vectors from the parent package's yk_test.go.
+var (
+	ykvalTestUid = yubikey.NewUid([]byte{
+		0x87, 0x92, 0xeb, 0xfe, 0x26, 0xcc,
+	})
+	ykvalTestKey = yubikey.NewKey([]byte{
+		0xec, 0xde, 0x18, 0xdb, 0xe7, 0x6f, 0xbd, 0x0c,
+		0x33, 0x33, 0x0f, 0x1c, 0x35, 0x48, 0x71, 0xdb,
+	})
+)
+
+const ykvalTestClientSecret = "dGVzdHNlY3JldHRlc3RzZWNyZXQ=" // base64("testsecrettestsecret")
+
+var ykvalTests = []struct {
+	otp        func() string
+	replay     bool // Verify otp twice and check the second response
+	badSecret  bool // sign the request with the wrong client secret
+	wantStatus Status
+	wantErr    bool
+}{
+	{
+		otp:        func() string { return genTestOTP(1, 1) },
+		wantStatus: StatusOK,
+	},
+	{
+		otp:        func() string { return genTestOTP(2, 1) },
+		replay:     true,
+		wantStatus: StatusReplayedOTP,
+	},
+	{
+		otp:        func() string { return "garbagegarbagegarbagegarbagegarbagegarbage" },
+		wantStatus: StatusBadOTP,
+	},
+	{
+		otp:       func() string { return genTestOTP(3, 1) },
+		badSecret: true,
+		wantErr:   true,
+	},
+}
+
+func TestClientServer(t *testing.T) {
+	for x, test := range ykvalTests {
+		_, client := newTestServer(t)
+		if test.badSecret {
+			client.secret = []byte("wrong secret wrong secret")
+		}
+
+		otp := test.otp()
+		if test.replay {
+			if _, err := client.Verify(otp); err != nil {
+				t.Errorf("ykval test #%d: first Verify failed: %v", x, err)
+				continue
+			}
+		}
+
+		resp, err := client.Verify(otp)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ykval test #%d: expected Verify to fail", x)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ykval test #%d: Verify failed: %v", x, err)
+			continue
+		}
+		if resp.Status != test.wantStatus {
+			t.Errorf("ykval test #%d: got status %v want %v", x, resp.Status, test.wantStatus)
+			continue
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *Client) {
+	t.Helper()
+
+	publicID := string(yubikey.ModHexEncode(ykvalTestUid[:]))
+	keyring := yubikey.Keyring{publicID: ykvalTestKey}
+	validator := yubikey.NewValidator(keyring, yubikey.NewMemStore())
+
+	srv := httptest.NewServer(NewServer(validator, map[int][]byte{
+		1: mustDecodeSecret(t),
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(1, ykvalTestClientSecret, []string{srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return srv, client
+}
+
+func mustDecodeSecret(t *testing.T) []byte {
+	t.Helper()
+	c, err := NewClient(1, ykvalTestClientSecret, nil)
+	if err != nil {
+		t.Fatalf("decoding test secret failed: %v", err)
+	}
+	return c.secret
+}
+
+func genTestOTP(ctr uint16, use uint8) string {
+	publicID := string(yubikey.ModHexEncode(ykvalTestUid[:]))
+	token := yubikey.NewToken(ykvalTestUid, ctr, 0, 0, use, 0)
+	return publicID + token.Generate(ykvalTestKey).String()
+}